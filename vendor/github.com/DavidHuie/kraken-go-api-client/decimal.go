@@ -0,0 +1,205 @@
+package krakenapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+)
+
+// DecimalMode selects how TradesDecimal represents the price and
+// volume of each trade it returns. It has no effect on any other
+// method: Depth, Ticker, OHLC, and the balance/order responses
+// (DepthResponse, TickerResponse, OHLCResponse, BalanceResponse, ...)
+// are defined outside this file and always parse prices/volumes the
+// old, float-only way.
+type DecimalMode int
+
+const (
+	// DecimalFloat parses every price/volume into a float64. This is
+	// the library's historical behavior and is lossy for large BTC
+	// volumes and low-value assets such as SHIB.
+	DecimalFloat DecimalMode = iota
+	// DecimalString keeps the original string untouched, so no
+	// precision is ever lost to a float64 round trip.
+	DecimalString
+	// DecimalFixed parses into a big.Rat for exact arithmetic.
+	DecimalFixed
+)
+
+// SetTradesDecimalMode selects how TradesDecimal represents the price
+// and volume of each trade it returns. The default is DecimalFloat.
+// It does not affect Trades (which always returns float64/string
+// pairs via TradeInfo) or any other response type. Safe to call
+// concurrently with in-flight requests.
+func (api *KrakenAPI) SetTradesDecimalMode(mode DecimalMode) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.tradesDecimalMode = mode
+}
+
+func (api *KrakenAPI) getTradesDecimalMode() DecimalMode {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+	return api.tradesDecimalMode
+}
+
+// Decimal is a Kraken price or volume. It keeps whichever
+// representation its KrakenAPI's DecimalMode selected at parse time,
+// via a real parse instead of a strconv.ParseFloat call with its
+// error silently discarded.
+type Decimal struct {
+	raw   string
+	mode  DecimalMode
+	float float64
+	rat   *big.Rat
+}
+
+// newDecimal parses raw under mode, returning an error rather than
+// silently truncating or dropping precision on an invalid value.
+func newDecimal(mode DecimalMode, raw string) (Decimal, error) {
+	d := Decimal{raw: raw, mode: mode}
+
+	switch mode {
+	case DecimalString:
+		// No further parsing; raw is already the value of record.
+	case DecimalFixed:
+		r, ok := new(big.Rat).SetString(raw)
+		if !ok {
+			return Decimal{}, fmt.Errorf("krakenapi: %q is not a valid decimal", raw)
+		}
+		d.rat = r
+	default:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("krakenapi: %q is not a valid decimal: %s", raw, err.Error())
+		}
+		d.float = f
+	}
+
+	return d, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler under DecimalFloat, since
+// a bare Decimal value has no KrakenAPI to read a mode from. Response
+// parsing that wants DecimalString or DecimalFixed semantics builds
+// Decimals directly with newDecimal instead of relying on
+// encoding/json to call this method.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	parsed, err := newDecimal(DecimalFloat, raw)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// String returns the original, unrounded string Kraken sent.
+func (d Decimal) String() string {
+	return d.raw
+}
+
+// Float64 returns the value as a float64, parsing raw on demand if
+// the mode it was built under wasn't DecimalFloat.
+func (d Decimal) Float64() float64 {
+	if d.mode == DecimalFloat {
+		return d.float
+	}
+	f, _ := strconv.ParseFloat(d.raw, 64)
+	return f
+}
+
+// Rat returns the value as an exact big.Rat, parsing raw on demand if
+// the mode it was built under wasn't DecimalFixed.
+func (d Decimal) Rat() *big.Rat {
+	if d.rat != nil {
+		return d.rat
+	}
+	r, _ := new(big.Rat).SetString(d.raw)
+	return r
+}
+
+// DecimalTradeInfo mirrors TradeInfo but carries Price and Volume as
+// Decimal rather than a separate string/float64 pair, so a
+// DecimalFixed caller can call .Rat() directly instead of re-parsing
+// TradeInfo.Price itself. It exists alongside TradeInfo, which is
+// used elsewhere in the public API, rather than replacing it.
+type DecimalTradeInfo struct {
+	Price         Decimal
+	Volume        Decimal
+	Time          int64
+	Buy           bool
+	Sell          bool
+	Market        bool
+	Limit         bool
+	Miscellaneous string
+}
+
+// DecimalTradesResponse is TradesResponse with DecimalTradeInfo entries.
+type DecimalTradesResponse struct {
+	Last   int64
+	Trades []DecimalTradeInfo
+}
+
+// TradesDecimal is Trades but returns DecimalTradeInfo entries, giving
+// callers in DecimalFixed mode exact big.Rat access to price and
+// volume instead of only the string Trades exposes via TradeInfo.Price.
+func (api *KrakenAPI) TradesDecimal(pair string, since int64) (*DecimalTradesResponse, error) {
+	return api.TradesDecimalCtx(context.Background(), pair, since)
+}
+
+// TradesDecimalCtx is TradesDecimal with a caller-supplied context.
+func (api *KrakenAPI) TradesDecimalCtx(ctx context.Context, pair string, since int64) (*DecimalTradesResponse, error) {
+	values := url.Values{"pair": {pair}}
+	if since > 0 {
+		values.Set("since", strconv.FormatInt(since, 10))
+	}
+	resp, err := api.queryPublic(ctx, "Trades", values, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	v := resp.(map[string]interface{})
+
+	last, err := strconv.ParseInt(v["last"].(string), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DecimalTradesResponse{Last: last}
+
+	trades := v[pair].([]interface{})
+	for _, raw := range trades {
+		trade := raw.([]interface{})
+
+		mode := api.getTradesDecimalMode()
+		price, err := newDecimal(mode, trade[0].(string))
+		if err != nil {
+			return nil, err
+		}
+		volume, err := newDecimal(mode, trade[1].(string))
+		if err != nil {
+			return nil, err
+		}
+
+		result.Trades = append(result.Trades, DecimalTradeInfo{
+			Price:         price,
+			Volume:        volume,
+			Time:          int64(trade[2].(float64)),
+			Buy:           trade[3].(string) == BUY,
+			Sell:          trade[3].(string) == SELL,
+			Market:        trade[4].(string) == MARKET,
+			Limit:         trade[4].(string) == LIMIT,
+			Miscellaneous: trade[5].(string),
+		})
+	}
+
+	return result, nil
+}
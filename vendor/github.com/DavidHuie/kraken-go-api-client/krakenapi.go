@@ -1,6 +1,7 @@
 package krakenapi
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -13,6 +14,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,6 +25,11 @@ const (
 	APIVersion = "0"
 	// APIUserAgent identifies this library with the Kraken API
 	APIUserAgent = "Kraken GO API Agent (https://github.com/beldur/kraken-go-api-client)"
+
+	// defaultHTTPTimeout is applied by NewWithClient when the caller's
+	// http.Client has no timeout set, so a Kraken outage can't hang a
+	// caller forever on http.DefaultClient.
+	defaultHTTPTimeout = 30 * time.Second
 )
 
 // List of valid public methods
@@ -52,13 +59,33 @@ var privateMethods = []string{
 	"TradeVolume",
 	"AddOrder",
 	"CancelOrder",
+	"GetWebSocketsToken",
 }
 
 // KrakenAPI represents a Kraken API Client connection
 type KrakenAPI struct {
-	key    string
-	secret string
-	client *http.Client
+	key     string
+	secret  string
+	client  *http.Client
+	limiter *rateLimiter
+
+	// mu guards every field below that SetRetryPolicy, OnRetry,
+	// SetNonce, and SetTradesDecimalMode can overwrite after
+	// construction, since a KrakenAPI is meant to be shared across
+	// goroutines issuing concurrent requests.
+	mu          sync.RWMutex
+	retryConfig RetryConfig
+	onRetry     func(attempt int, err error)
+	nonce       Nonce
+	// tradesDecimalMode only affects TradesDecimal; see
+	// DecimalMode's doc comment.
+	tradesDecimalMode DecimalMode
+
+	// clockOffset and lastNonce are updated via the sync/atomic
+	// package instead of mu, since they're written on every private
+	// request rather than only on an explicit Set call.
+	clockOffset int64
+	lastNonce   int64
 }
 
 // New creates a new Kraken API client
@@ -66,14 +93,68 @@ func New(key, secret string) *KrakenAPI {
 	return NewWithClient(key, secret, http.DefaultClient)
 }
 
-// NewWithClient creates a new Kraken API but with the given client
+// NewWithClient creates a new Kraken API but with the given client. If
+// httpClient has no Timeout set, a default of 30s is applied so a
+// stalled Kraken request can't hang a caller indefinitely. A client
+// with a non-zero Timeout keeps it; a Timeout of 0 is always replaced,
+// so there is no way to run without a client-level timeout.
 func NewWithClient(key, secret string, httpClient *http.Client) *KrakenAPI {
-	return &KrakenAPI{key, secret, httpClient}
+	if httpClient.Timeout == 0 {
+		clone := *httpClient
+		clone.Timeout = defaultHTTPTimeout
+		httpClient = &clone
+	}
+
+	return &KrakenAPI{
+		key:         key,
+		secret:      secret,
+		client:      httpClient,
+		retryConfig: DefaultRetryConfig,
+		limiter:     newRateLimiter(),
+		nonce:       newMonotonicNonce(0),
+	}
+}
+
+// SetRetryPolicy overrides the retry behavior used by doRequest. The
+// zero value of RetryConfig disables retries (MaxAttempts of 0 is
+// treated as 1: a single, non-retried attempt). Safe to call
+// concurrently with in-flight requests.
+func (api *KrakenAPI) SetRetryPolicy(cfg RetryConfig) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.retryConfig = cfg
+}
+
+func (api *KrakenAPI) getRetryConfig() RetryConfig {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+	return api.retryConfig
+}
+
+// OnRetry registers a callback invoked just before each retried
+// attempt, with the retry number (1 for the first retry, i.e. the
+// second attempt) and the error that triggered the retry. Safe to call concurrently with in-flight
+// requests.
+func (api *KrakenAPI) OnRetry(fn func(attempt int, err error)) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.onRetry = fn
+}
+
+func (api *KrakenAPI) getOnRetry() func(attempt int, err error) {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+	return api.onRetry
 }
 
 // Time returns the server's time
 func (api *KrakenAPI) Time() (*TimeResponse, error) {
-	resp, err := api.queryPublic("Time", nil, &TimeResponse{})
+	return api.TimeCtx(context.Background())
+}
+
+// TimeCtx is Time with a caller-supplied context.
+func (api *KrakenAPI) TimeCtx(ctx context.Context) (*TimeResponse, error) {
+	resp, err := api.queryPublic(ctx, "Time", nil, &TimeResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +164,12 @@ func (api *KrakenAPI) Time() (*TimeResponse, error) {
 
 // Assets returns the servers available assets
 func (api *KrakenAPI) Assets() (*AssetsResponse, error) {
-	resp, err := api.queryPublic("Assets", nil, &AssetsResponse{})
+	return api.AssetsCtx(context.Background())
+}
+
+// AssetsCtx is Assets with a caller-supplied context.
+func (api *KrakenAPI) AssetsCtx(ctx context.Context) (*AssetsResponse, error) {
+	resp, err := api.queryPublic(ctx, "Assets", nil, &AssetsResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +179,12 @@ func (api *KrakenAPI) Assets() (*AssetsResponse, error) {
 
 // AssetPairs returns the servers available asset pairs
 func (api *KrakenAPI) AssetPairs() (*AssetPairsResponse, error) {
-	resp, err := api.queryPublic("AssetPairs", nil, &AssetPairsResponse{})
+	return api.AssetPairsCtx(context.Background())
+}
+
+// AssetPairsCtx is AssetPairs with a caller-supplied context.
+func (api *KrakenAPI) AssetPairsCtx(ctx context.Context) (*AssetPairsResponse, error) {
+	resp, err := api.queryPublic(ctx, "AssetPairs", nil, &AssetPairsResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +194,12 @@ func (api *KrakenAPI) AssetPairs() (*AssetPairsResponse, error) {
 
 // Ticker returns the ticker for given comma separated pairs
 func (api *KrakenAPI) Ticker(pairs ...string) (*TickerResponse, error) {
-	resp, err := api.queryPublic("Ticker", url.Values{
+	return api.TickerCtx(context.Background(), pairs...)
+}
+
+// TickerCtx is Ticker with a caller-supplied context.
+func (api *KrakenAPI) TickerCtx(ctx context.Context, pairs ...string) (*TickerResponse, error) {
+	resp, err := api.queryPublic(ctx, "Ticker", url.Values{
 		"pair": {strings.Join(pairs, ",")},
 	}, &TickerResponse{})
 	if err != nil {
@@ -115,6 +211,11 @@ func (api *KrakenAPI) Ticker(pairs ...string) (*TickerResponse, error) {
 
 // OHLC returns a OHLCResponse struct based on the given pair
 func (api *KrakenAPI) OHLC(pair string, last ...int64) (*OHLCResponse, error) {
+	return api.OHLCCtx(context.Background(), pair, last...)
+}
+
+// OHLCCtx is OHLC with a caller-supplied context.
+func (api *KrakenAPI) OHLCCtx(ctx context.Context, pair string, last ...int64) (*OHLCResponse, error) {
 	urlValue := url.Values{}
 	urlValue.Add("pair", pair)
 
@@ -123,7 +224,7 @@ func (api *KrakenAPI) OHLC(pair string, last ...int64) (*OHLCResponse, error) {
 	}
 
 	// Returns a map[string]interface{} as an interface{}
-	interfaceResponse, err := api.queryPublic("OHLC", urlValue, nil)
+	interfaceResponse, err := api.queryPublic(ctx, "OHLC", urlValue, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -151,11 +252,16 @@ func (api *KrakenAPI) OHLC(pair string, last ...int64) (*OHLCResponse, error) {
 
 // Trades returns the recent trades for given pair
 func (api *KrakenAPI) Trades(pair string, since int64) (*TradesResponse, error) {
+	return api.TradesCtx(context.Background(), pair, since)
+}
+
+// TradesCtx is Trades with a caller-supplied context.
+func (api *KrakenAPI) TradesCtx(ctx context.Context, pair string, since int64) (*TradesResponse, error) {
 	values := url.Values{"pair": {pair}}
 	if since > 0 {
 		values.Set("since", strconv.FormatInt(since, 10))
 	}
-	resp, err := api.queryPublic("Trades", values, nil)
+	resp, err := api.queryPublic(ctx, "Trades", values, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -176,17 +282,22 @@ func (api *KrakenAPI) Trades(pair string, since int64) (*TradesResponse, error)
 	for _, v := range trades {
 		trade := v.([]interface{})
 
-		priceString := trade[0].(string)
-		price, _ := strconv.ParseFloat(priceString, 64)
+		mode := api.getTradesDecimalMode()
+		price, err := newDecimal(mode, trade[0].(string))
+		if err != nil {
+			return nil, err
+		}
 
-		volumeString := trade[1].(string)
-		volume, _ := strconv.ParseFloat(trade[1].(string), 64)
+		volume, err := newDecimal(mode, trade[1].(string))
+		if err != nil {
+			return nil, err
+		}
 
 		tradeInfo := TradeInfo{
-			Price:         priceString,
-			PriceFloat:    price,
-			Volume:        volumeString,
-			VolumeFloat:   volume,
+			Price:         price.String(),
+			PriceFloat:    price.Float64(),
+			Volume:        volume.String(),
+			VolumeFloat:   volume.Float64(),
 			Time:          int64(trade[2].(float64)),
 			Buy:           trade[3].(string) == BUY,
 			Sell:          trade[3].(string) == SELL,
@@ -203,7 +314,12 @@ func (api *KrakenAPI) Trades(pair string, since int64) (*TradesResponse, error)
 
 // Balance returns all account asset balances
 func (api *KrakenAPI) Balance() (*BalanceResponse, error) {
-	resp, err := api.queryPrivate("Balance", url.Values{}, &BalanceResponse{})
+	return api.BalanceCtx(context.Background())
+}
+
+// BalanceCtx is Balance with a caller-supplied context.
+func (api *KrakenAPI) BalanceCtx(ctx context.Context) (*BalanceResponse, error) {
+	resp, err := api.queryPrivate(ctx, "Balance", url.Values{}, &BalanceResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -213,6 +329,11 @@ func (api *KrakenAPI) Balance() (*BalanceResponse, error) {
 
 // OpenOrders returns all open orders
 func (api *KrakenAPI) OpenOrders(args map[string]string) (*OpenOrdersResponse, error) {
+	return api.OpenOrdersCtx(context.Background(), args)
+}
+
+// OpenOrdersCtx is OpenOrders with a caller-supplied context.
+func (api *KrakenAPI) OpenOrdersCtx(ctx context.Context, args map[string]string) (*OpenOrdersResponse, error) {
 	params := url.Values{}
 	if value, ok := args["trades"]; ok {
 		params.Add("trades", value)
@@ -221,7 +342,7 @@ func (api *KrakenAPI) OpenOrders(args map[string]string) (*OpenOrdersResponse, e
 		params.Add("userref", value)
 	}
 
-	resp, err := api.queryPrivate("OpenOrders", params, &OpenOrdersResponse{})
+	resp, err := api.queryPrivate(ctx, "OpenOrders", params, &OpenOrdersResponse{})
 
 	if err != nil {
 		return nil, err
@@ -232,6 +353,11 @@ func (api *KrakenAPI) OpenOrders(args map[string]string) (*OpenOrdersResponse, e
 
 // ClosedOrders returns all closed orders
 func (api *KrakenAPI) ClosedOrders(args map[string]string) (*ClosedOrdersResponse, error) {
+	return api.ClosedOrdersCtx(context.Background(), args)
+}
+
+// ClosedOrdersCtx is ClosedOrders with a caller-supplied context.
+func (api *KrakenAPI) ClosedOrdersCtx(ctx context.Context, args map[string]string) (*ClosedOrdersResponse, error) {
 	params := url.Values{}
 	if value, ok := args["trades"]; ok {
 		params.Add("trades", value)
@@ -251,7 +377,7 @@ func (api *KrakenAPI) ClosedOrders(args map[string]string) (*ClosedOrdersRespons
 	if value, ok := args["closetime"]; ok {
 		params.Add("closetime", value)
 	}
-	resp, err := api.queryPrivate("ClosedOrders", params, &ClosedOrdersResponse{})
+	resp, err := api.queryPrivate(ctx, "ClosedOrders", params, &ClosedOrdersResponse{})
 
 	if err != nil {
 		return nil, err
@@ -262,8 +388,13 @@ func (api *KrakenAPI) ClosedOrders(args map[string]string) (*ClosedOrdersRespons
 
 // Depth returns the order book for given pair and orders count.
 func (api *KrakenAPI) Depth(pair string, count int) (*OrderBook, error) {
+	return api.DepthCtx(context.Background(), pair, count)
+}
+
+// DepthCtx is Depth with a caller-supplied context.
+func (api *KrakenAPI) DepthCtx(ctx context.Context, pair string, count int) (*OrderBook, error) {
 	dr := DepthResponse{}
-	_, err := api.queryPublic("Depth", url.Values{
+	_, err := api.queryPublic(ctx, "Depth", url.Values{
 		"pair": {pair}, "count": {strconv.Itoa(count)},
 	}, &dr)
 
@@ -280,9 +411,14 @@ func (api *KrakenAPI) Depth(pair string, count int) (*OrderBook, error) {
 
 // CancelOrder cancels order
 func (api *KrakenAPI) CancelOrder(txid string) (*CancelOrderResponse, error) {
+	return api.CancelOrderCtx(context.Background(), txid)
+}
+
+// CancelOrderCtx is CancelOrder with a caller-supplied context.
+func (api *KrakenAPI) CancelOrderCtx(ctx context.Context, txid string) (*CancelOrderResponse, error) {
 	params := url.Values{}
 	params.Add("txid", txid)
-	resp, err := api.queryPrivate("CancelOrder", params, &CancelOrderResponse{})
+	resp, err := api.queryPrivate(ctx, "CancelOrder", params, &CancelOrderResponse{})
 
 	if err != nil {
 		return nil, err
@@ -293,6 +429,11 @@ func (api *KrakenAPI) CancelOrder(txid string) (*CancelOrderResponse, error) {
 
 // QueryOrders shows order
 func (api *KrakenAPI) QueryOrders(txids string, args map[string]string) (*QueryOrdersResponse, error) {
+	return api.QueryOrdersCtx(context.Background(), txids, args)
+}
+
+// QueryOrdersCtx is QueryOrders with a caller-supplied context.
+func (api *KrakenAPI) QueryOrdersCtx(ctx context.Context, txids string, args map[string]string) (*QueryOrdersResponse, error) {
 	params := url.Values{"txid": {txids}}
 	if value, ok := args["trades"]; ok {
 		params.Add("trades", value)
@@ -300,7 +441,7 @@ func (api *KrakenAPI) QueryOrders(txids string, args map[string]string) (*QueryO
 	if value, ok := args["userref"]; ok {
 		params.Add("userref", value)
 	}
-	resp, err := api.queryPrivate("QueryOrders", params, &QueryOrdersResponse{})
+	resp, err := api.queryPrivate(ctx, "QueryOrders", params, &QueryOrdersResponse{})
 
 	if err != nil {
 		return nil, err
@@ -311,6 +452,11 @@ func (api *KrakenAPI) QueryOrders(txids string, args map[string]string) (*QueryO
 
 // AddOrder adds new order
 func (api *KrakenAPI) AddOrder(pair string, direction string, orderType string, volume string, args map[string]string) (*AddOrderResponse, error) {
+	return api.AddOrderCtx(context.Background(), pair, direction, orderType, volume, args)
+}
+
+// AddOrderCtx is AddOrder with a caller-supplied context.
+func (api *KrakenAPI) AddOrderCtx(ctx context.Context, pair string, direction string, orderType string, volume string, args map[string]string) (*AddOrderResponse, error) {
 	params := url.Values{
 		"pair":      {pair},
 		"type":      {direction},
@@ -351,7 +497,7 @@ func (api *KrakenAPI) AddOrder(pair string, direction string, orderType string,
 	if value, ok := args["trading_agreement"]; ok {
 		params.Add("trading_agreement", value)
 	}
-	resp, err := api.queryPrivate("AddOrder", params, &AddOrderResponse{})
+	resp, err := api.queryPrivate(ctx, "AddOrder", params, &AddOrderResponse{})
 
 	if err != nil {
 		return nil, err
@@ -362,6 +508,11 @@ func (api *KrakenAPI) AddOrder(pair string, direction string, orderType string,
 
 // Query sends a query to Kraken api for given method and parameters
 func (api *KrakenAPI) Query(method string, data map[string]string) (interface{}, error) {
+	return api.QueryCtx(context.Background(), method, data)
+}
+
+// QueryCtx is Query with a caller-supplied context.
+func (api *KrakenAPI) QueryCtx(ctx context.Context, method string, data map[string]string) (interface{}, error) {
 	values := url.Values{}
 	for key, value := range data {
 		values.Set(key, value)
@@ -369,28 +520,28 @@ func (api *KrakenAPI) Query(method string, data map[string]string) (interface{},
 
 	// Check if method is public or private
 	if isStringInSlice(method, publicMethods) {
-		return api.queryPublic(method, values, nil)
+		return api.queryPublic(ctx, method, values, nil)
 	} else if isStringInSlice(method, privateMethods) {
-		return api.queryPrivate(method, values, nil)
+		return api.queryPrivate(ctx, method, values, nil)
 	}
 
 	return nil, fmt.Errorf("Method '%s' is not valid", method)
 }
 
 // Execute a public method query
-func (api *KrakenAPI) queryPublic(method string, values url.Values, typ interface{}) (interface{}, error) {
+func (api *KrakenAPI) queryPublic(ctx context.Context, method string, values url.Values, typ interface{}) (interface{}, error) {
 	url := fmt.Sprintf("%s/%s/public/%s", APIURL, APIVersion, method)
-	resp, err := api.doRequest(url, values, nil, typ)
+	resp, err := api.doRequest(ctx, method, false, url, values, nil, typ)
 
 	return resp, err
 }
 
 // queryPrivate executes a private method query
-func (api *KrakenAPI) queryPrivate(method string, values url.Values, typ interface{}) (interface{}, error) {
+func (api *KrakenAPI) queryPrivate(ctx context.Context, method string, values url.Values, typ interface{}) (interface{}, error) {
 	urlPath := fmt.Sprintf("/%s/private/%s", APIVersion, method)
 	reqURL := fmt.Sprintf("%s%s", APIURL, urlPath)
 	secret, _ := base64.StdEncoding.DecodeString(api.secret)
-	values.Set("nonce", fmt.Sprintf("%d", time.Now().UnixNano()))
+	values.Set("nonce", fmt.Sprintf("%d", api.nextPrivateNonce()))
 
 	// Create signature
 	signature := createSignature(urlPath, values, secret)
@@ -401,18 +552,62 @@ func (api *KrakenAPI) queryPrivate(method string, values url.Values, typ interfa
 		"API-Sign": signature,
 	}
 
-	resp, err := api.doRequest(reqURL, values, headers, typ)
+	resp, err := api.doRequest(ctx, method, true, reqURL, values, headers, typ)
 
 	return resp, err
 }
 
-// doRequest executes a HTTP Request to the Kraken API and returns the result
-func (api *KrakenAPI) doRequest(reqURL string, values url.Values, headers map[string]string, typ interface{}) (interface{}, error) {
+// doRequest runs doRequestOnce under the configured retry policy and
+// rate limiter, retrying transient failures (transport errors, HTTP
+// 5xx, and Kraken errors such as EAPI:Rate limit exceeded) with
+// exponential backoff while letting non-retryable errors such as
+// EOrder:* and auth failures fail fast. ctx is checked between
+// attempts so a caller can cancel a request stuck in backoff.
+func (api *KrakenAPI) doRequest(ctx context.Context, method string, private bool, reqURL string, values url.Values, headers map[string]string, typ interface{}) (interface{}, error) {
+	retryConfig := api.getRetryConfig()
+	attempts := retryConfig.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if onRetry := api.getOnRetry(); onRetry != nil {
+				onRetry(attempt, lastErr)
+			}
+			select {
+			case <-time.After(retryConfig.delay(attempt - 1)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		if err := api.limiter.wait(ctx, method, private); err != nil {
+			return nil, err
+		}
+
+		resp, err := api.doRequestOnce(ctx, reqURL, values, headers, typ)
+		if err == nil {
+			return resp, nil
+		}
+
+		lastErr = err
+		if !isRetryableErr(err) {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+// doRequestOnce executes a single HTTP Request to the Kraken API and returns the result
+func (api *KrakenAPI) doRequestOnce(ctx context.Context, reqURL string, values url.Values, headers map[string]string, typ interface{}) (interface{}, error) {
 
 	// Create request
-	req, err := http.NewRequest("POST", reqURL, strings.NewReader(values.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, strings.NewReader(values.Encode()))
 	if err != nil {
-		return nil, fmt.Errorf("Could not execute request! (%s)", err.Error())
+		return nil, &transportError{err}
 	}
 
 	req.Header.Add("User-Agent", APIUserAgent)
@@ -423,14 +618,21 @@ func (api *KrakenAPI) doRequest(reqURL string, values url.Values, headers map[st
 	// Execute request
 	resp, err := api.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("Could not execute request! (%s)", err.Error())
+		return nil, &transportError{err}
 	}
 	defer resp.Body.Close()
 
+	// A 5xx means Kraken itself failed to process the request; treat
+	// it like a transport error (always retryable) rather than reading
+	// a body that may or may not contain a usable error field.
+	if resp.StatusCode >= 500 {
+		return nil, &transportError{fmt.Errorf("unexpected status %d", resp.StatusCode)}
+	}
+
 	// Read request
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("Could not execute request! (%s)", err.Error())
+		return nil, &transportError{err}
 	}
 
 	// Parse request
@@ -444,12 +646,12 @@ func (api *KrakenAPI) doRequest(reqURL string, values url.Values, headers map[st
 
 	err = json.Unmarshal(body, &jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("Could not execute request! (%s)", err.Error())
+		return nil, &transportError{err}
 	}
 
 	// Check for Kraken API error
 	if len(jsonData.Error) > 0 {
-		return nil, fmt.Errorf("Could not execute request! (%s)", jsonData.Error)
+		return nil, &apiError{msg: fmt.Sprintf("%s", jsonData.Error)}
 	}
 
 	return jsonData.Result, nil
@@ -0,0 +1,168 @@
+package krakenapi
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Nonce supplies the strictly increasing values private requests sign
+// and send as the "nonce" parameter. The default, installed by New
+// and NewWithClient, is a process-local monotonic counter; use
+// NewFileNonce to share a sequence across restarts or processes.
+type Nonce interface {
+	Next() int64
+}
+
+// monotonicNonce is an atomically incremented counter seeded from the
+// larger of the current time (in nanoseconds) and a caller-supplied
+// floor, so a value it hands out can never regress within a process
+// even if the system clock steps backward.
+type monotonicNonce struct {
+	counter int64
+}
+
+func newMonotonicNonce(floor int64) *monotonicNonce {
+	seed := time.Now().UnixNano()
+	if floor > seed {
+		seed = floor
+	}
+	return &monotonicNonce{counter: seed}
+}
+
+func (n *monotonicNonce) Next() int64 {
+	return atomic.AddInt64(&n.counter, 1)
+}
+
+// NonceStore persists the last nonce issued so a FileNonce can resume
+// past it after a restart.
+type NonceStore interface {
+	Load() (int64, error)
+	Save(int64) error
+}
+
+// FileNonceStore is a NonceStore backed by a single file, fsync'd on
+// every write so a crash immediately after Save never loses the
+// persisted value.
+type FileNonceStore struct {
+	path string
+}
+
+// NewFileNonceStore returns a FileNonceStore that persists to path.
+func NewFileNonceStore(path string) *FileNonceStore {
+	return &FileNonceStore{path: path}
+}
+
+// Load reads the last persisted nonce, or 0 if path does not exist yet.
+func (s *FileNonceStore) Load() (int64, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	if _, err := fmt.Sscanf(string(data), "%d", &n); err != nil {
+		return 0, fmt.Errorf("krakenapi: corrupt nonce file %s: %s", s.path, err.Error())
+	}
+	return n, nil
+}
+
+// Save writes n to path, fsyncing before returning.
+func (s *FileNonceStore) Save(n int64) error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%d", n); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// FileNonce is a Nonce that persists every value it hands out to a
+// NonceStore before returning it, so a restarted process (or a second
+// process sharing the same API key) resumes past the last used value
+// instead of risking EAPI:Invalid nonce.
+type FileNonce struct {
+	mu    sync.Mutex
+	inner *monotonicNonce
+	store NonceStore
+}
+
+// NewFileNonce loads the last persisted nonce from store and returns
+// a FileNonce seeded past it.
+func NewFileNonce(store NonceStore) (*FileNonce, error) {
+	last, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &FileNonce{inner: newMonotonicNonce(last + 1), store: store}, nil
+}
+
+func (n *FileNonce) Next() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	v := n.inner.Next()
+	// Best-effort: a failed save only risks reusing a nonce after an
+	// unclean shutdown, which surfaces as EAPI:Invalid nonce and is
+	// recoverable by installing a fresh Nonce via SetNonce.
+	_ = n.store.Save(v)
+	return v
+}
+
+// SetNonce overrides the Nonce implementation used to sign private
+// requests. Safe to call concurrently with in-flight requests.
+func (api *KrakenAPI) SetNonce(n Nonce) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.nonce = n
+}
+
+func (api *KrakenAPI) getNonce() Nonce {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+	return api.nonce
+}
+
+// SyncTimeOffset queries the server's time via Time and records the
+// difference from the local clock, so nonces generated afterward are
+// adjusted for clock skew rather than risking EAPI:Invalid nonce. The
+// offset alone is not enough to guarantee monotonicity if this is
+// called more than once in a process's lifetime and the recomputed
+// offset drops - nextPrivateNonce clamps against the last nonce
+// actually issued, so that can never regress a nonce either.
+func (api *KrakenAPI) SyncTimeOffset() error {
+	t, err := api.Time()
+	if err != nil {
+		return err
+	}
+
+	serverNanos := t.Unixtime * int64(time.Second)
+	atomic.StoreInt64(&api.clockOffset, serverNanos-time.Now().UnixNano())
+	return nil
+}
+
+// nextPrivateNonce combines the Nonce counter with the clock-skew
+// offset and clamps the result so it is always strictly greater than
+// the last nonce this KrakenAPI issued, even if a concurrent
+// SyncTimeOffset call just moved the offset backward.
+func (api *KrakenAPI) nextPrivateNonce() int64 {
+	for {
+		candidate := api.getNonce().Next() + atomic.LoadInt64(&api.clockOffset)
+		last := atomic.LoadInt64(&api.lastNonce)
+		if candidate <= last {
+			candidate = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&api.lastNonce, last, candidate) {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package krakenapi
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestMonotonicNonceNeverRegresses(t *testing.T) {
+	n := newMonotonicNonce(0)
+	prev := n.Next()
+	for i := 0; i < 1000; i++ {
+		next := n.Next()
+		if next <= prev {
+			t.Fatalf("Next() = %d, want > previous value %d", next, prev)
+		}
+		prev = next
+	}
+}
+
+func TestMonotonicNonceHonorsFloor(t *testing.T) {
+	n := newMonotonicNonce(1 << 62)
+	if got := n.Next(); got <= 1<<62 {
+		t.Errorf("Next() = %d, want > floor (%d)", got, int64(1)<<62)
+	}
+}
+
+func TestNextPrivateNonceClampsAgainstDroppedOffset(t *testing.T) {
+	api := &KrakenAPI{nonce: newMonotonicNonce(0)}
+
+	atomic.StoreInt64(&api.clockOffset, 1_000_000)
+	first := api.nextPrivateNonce()
+
+	// A resync that drops the offset (simulating SyncTimeOffset being
+	// called again after the local clock caught up) must not be able
+	// to produce a nonce <= one already issued.
+	atomic.StoreInt64(&api.clockOffset, -1_000_000)
+	second := api.nextPrivateNonce()
+
+	if second <= first {
+		t.Errorf("nonce after offset dropped = %d, want > previous nonce %d", second, first)
+	}
+}
+
+func TestFileNonceStorePersistsAcrossInstances(t *testing.T) {
+	store := NewFileNonceStore(t.TempDir() + "/nonce")
+
+	n1, err := NewFileNonce(store)
+	if err != nil {
+		t.Fatalf("NewFileNonce: %s", err)
+	}
+	last := n1.Next()
+
+	n2, err := NewFileNonce(store)
+	if err != nil {
+		t.Fatalf("NewFileNonce (second instance): %s", err)
+	}
+	if got := n2.Next(); got <= last {
+		t.Errorf("Next() after reload = %d, want > %d", got, last)
+	}
+}
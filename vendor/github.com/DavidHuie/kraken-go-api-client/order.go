@@ -0,0 +1,413 @@
+package krakenapi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Order types accepted by AddOrder's ordertype parameter.
+const (
+	OrderTypeMarket            = "market"
+	OrderTypeLimit             = "limit"
+	OrderTypeStopLoss          = "stop-loss"
+	OrderTypeTakeProfit        = "take-profit"
+	OrderTypeStopLossLimit     = "stop-loss-limit"
+	OrderTypeTakeProfitLimit   = "take-profit-limit"
+	OrderTypeStopLossAndLimit  = "stop-loss-and-limit"
+	OrderTypeSettlePosition    = "settle-position"
+	OrderTypeTrailingStop      = "trailing-stop"
+	OrderTypeTrailingStopLimit = "trailing-stop-limit"
+)
+
+// Time-in-force modes accepted by AddOrder's timeinforce parameter.
+const (
+	GTC = "GTC"
+	IOC = "IOC"
+	GTD = "GTD"
+)
+
+// oflags values accepted by AddOrder's oflags parameter.
+const (
+	OFlagPostOnly = "post"
+	OFlagFCIB     = "fcib"
+	OFlagFCIQ     = "fciq"
+	OFlagNoMPP    = "nompp"
+	OFlagVIQC     = "viqc"
+)
+
+// AssetPairInfo is the subset of AssetPairs() fields OrderRequest
+// needs to round prices/volumes and reject undersized orders.
+type AssetPairInfo struct {
+	PairDecimals int
+	LotDecimals  int
+	OrderMin     string
+}
+
+// AssetPairsCache caches tick size and lot decimals per pair so
+// OrderRequest can round and validate orders without a round trip to
+// AssetPairs() per order.
+type AssetPairsCache struct {
+	mu    sync.RWMutex
+	pairs map[string]AssetPairInfo
+}
+
+// NewAssetPairsCache returns an empty cache; call Refresh to populate it.
+func NewAssetPairsCache() *AssetPairsCache {
+	return &AssetPairsCache{pairs: make(map[string]AssetPairInfo)}
+}
+
+// Refresh repopulates the cache from api.AssetPairs().
+func (c *AssetPairsCache) Refresh(api *KrakenAPI) error {
+	resp, err := api.AssetPairs()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, pair := range *resp {
+		c.pairs[name] = AssetPairInfo{
+			PairDecimals: pair.PairDecimals,
+			LotDecimals:  pair.LotDecimals,
+			OrderMin:     pair.OrderMin,
+		}
+	}
+	return nil
+}
+
+// Get returns the cached info for pair, or nil if it hasn't been
+// fetched by Refresh.
+func (c *AssetPairsCache) Get(pair string) *AssetPairInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if info, ok := c.pairs[pair]; ok {
+		return &info
+	}
+	return nil
+}
+
+// OrderRequest builds the parameters for AddOrder with a typed,
+// validated API in place of a stringly-typed args map. Build one with
+// NewOrder, chain the Buy/Sell, order-type, and modifier methods, then
+// call Send to submit it.
+//
+//	req := NewOrder("XXBTZUSD").Buy().Limit(30000).Volume(0.01).PostOnly()
+//	resp, err := req.Send(api)
+type OrderRequest struct {
+	pair      string
+	direction string
+	orderType string
+	volume    float64
+	price     float64
+	price2    float64
+	leverage  string
+	oflags    []string
+	tif       string
+	expireTM  int64
+	startTM   int64
+
+	closeType   string
+	closePrice  float64
+	closePrice2 float64
+
+	validate bool
+	pairInfo *AssetPairInfo
+	// pairsCache is set by WithAssetPairs so Validate can tell a
+	// missing cache entry (an error) apart from WithAssetPairs never
+	// having been called (rounding/ordermin simply don't apply).
+	pairsCache *AssetPairsCache
+}
+
+// NewOrder starts building an order for pair. The order defaults to a
+// market order; call Limit, StopLoss, etc. to change its type.
+func NewOrder(pair string) *OrderRequest {
+	return &OrderRequest{pair: pair, orderType: OrderTypeMarket}
+}
+
+// Buy marks the order as a buy.
+func (o *OrderRequest) Buy() *OrderRequest {
+	o.direction = BUY
+	return o
+}
+
+// Sell marks the order as a sell.
+func (o *OrderRequest) Sell() *OrderRequest {
+	o.direction = SELL
+	return o
+}
+
+// Volume sets the order volume, in the pair's base currency.
+func (o *OrderRequest) Volume(v float64) *OrderRequest {
+	o.volume = v
+	return o
+}
+
+// Market makes this a market order.
+func (o *OrderRequest) Market() *OrderRequest {
+	o.orderType = OrderTypeMarket
+	return o
+}
+
+// Limit makes this a limit order at price.
+func (o *OrderRequest) Limit(price float64) *OrderRequest {
+	o.orderType = OrderTypeLimit
+	o.price = price
+	return o
+}
+
+// StopLoss makes this a stop-loss order triggered at trigger.
+func (o *OrderRequest) StopLoss(trigger float64) *OrderRequest {
+	o.orderType = OrderTypeStopLoss
+	o.price = trigger
+	return o
+}
+
+// TakeProfit makes this a take-profit order triggered at trigger.
+func (o *OrderRequest) TakeProfit(trigger float64) *OrderRequest {
+	o.orderType = OrderTypeTakeProfit
+	o.price = trigger
+	return o
+}
+
+// StopLossLimit makes this a stop-loss-limit order: triggered at
+// trigger, executed as a limit order at limit.
+func (o *OrderRequest) StopLossLimit(trigger, limit float64) *OrderRequest {
+	o.orderType = OrderTypeStopLossLimit
+	o.price = trigger
+	o.price2 = limit
+	return o
+}
+
+// TakeProfitLimit makes this a take-profit-limit order: triggered at
+// trigger, executed as a limit order at limit.
+func (o *OrderRequest) TakeProfitLimit(trigger, limit float64) *OrderRequest {
+	o.orderType = OrderTypeTakeProfitLimit
+	o.price = trigger
+	o.price2 = limit
+	return o
+}
+
+// StopLossAndLimit makes this a stop-loss-and-limit order: triggered
+// at trigger, executed as a limit order at limit.
+func (o *OrderRequest) StopLossAndLimit(trigger, limit float64) *OrderRequest {
+	o.orderType = OrderTypeStopLossAndLimit
+	o.price = trigger
+	o.price2 = limit
+	return o
+}
+
+// TrailingStop makes this a trailing-stop order with the given offset.
+func (o *OrderRequest) TrailingStop(offset float64) *OrderRequest {
+	o.orderType = OrderTypeTrailingStop
+	o.price = offset
+	return o
+}
+
+// TrailingStopLimit makes this a trailing-stop-limit order with the
+// given trigger offset and limit offset.
+func (o *OrderRequest) TrailingStopLimit(triggerOffset, limitOffset float64) *OrderRequest {
+	o.orderType = OrderTypeTrailingStopLimit
+	o.price = triggerOffset
+	o.price2 = limitOffset
+	return o
+}
+
+// Leverage requests leverage of x:1 on the order.
+func (o *OrderRequest) Leverage(x int) *OrderRequest {
+	o.leverage = strconv.Itoa(x)
+	return o
+}
+
+// PostOnly sets the post-only order flag, rejecting the order rather
+// than letting it take liquidity.
+func (o *OrderRequest) PostOnly() *OrderRequest { return o.flag(OFlagPostOnly) }
+
+// FCIB prefers fee currency in base currency.
+func (o *OrderRequest) FCIB() *OrderRequest { return o.flag(OFlagFCIB) }
+
+// FCIQ prefers fee currency in quote currency.
+func (o *OrderRequest) FCIQ() *OrderRequest { return o.flag(OFlagFCIQ) }
+
+// NoMarketPriceProtection disables market price protection.
+func (o *OrderRequest) NoMarketPriceProtection() *OrderRequest { return o.flag(OFlagNoMPP) }
+
+// VIQC sets volume in quote currency.
+func (o *OrderRequest) VIQC() *OrderRequest { return o.flag(OFlagVIQC) }
+
+func (o *OrderRequest) flag(f string) *OrderRequest {
+	for _, existing := range o.oflags {
+		if existing == f {
+			return o
+		}
+	}
+	o.oflags = append(o.oflags, f)
+	return o
+}
+
+// TimeInForce sets the order's time-in-force. expireTM is only used
+// when tif is GTD, as a Unix timestamp.
+func (o *OrderRequest) TimeInForce(tif string, expireTM int64) *OrderRequest {
+	o.tif = tif
+	o.expireTM = expireTM
+	return o
+}
+
+// ValidateOnly marks this a validate-only order: Kraken checks it without
+// submitting it to the order book.
+func (o *OrderRequest) ValidateOnly() *OrderRequest {
+	o.validate = true
+	return o
+}
+
+// CloseStopLossLimit attaches a conditional close, submitted as part
+// of this order, that becomes a stop-loss-limit order once this order
+// fills.
+func (o *OrderRequest) CloseStopLossLimit(trigger, limit float64) *OrderRequest {
+	o.closeType = OrderTypeStopLossLimit
+	o.closePrice = trigger
+	o.closePrice2 = limit
+	return o
+}
+
+// WithAssetPairs attaches a cache used to round prices/volumes to the
+// pair's tick size and lot size, and to reject orders below ordermin.
+// Validate (and so Params/Send) fails if the cache has no entry for
+// this order's pair, rather than silently skipping rounding and the
+// ordermin check.
+func (o *OrderRequest) WithAssetPairs(cache *AssetPairsCache) *OrderRequest {
+	o.pairsCache = cache
+	o.pairInfo = cache.Get(o.pair)
+	return o
+}
+
+// Validate checks the order for obvious mistakes and, if an
+// AssetPairsCache was attached via WithAssetPairs, rounds its price(s)
+// and volume to the pair's tick size and lot size.
+func (o *OrderRequest) Validate() error {
+	if o.direction != BUY && o.direction != SELL {
+		return errors.New("krakenapi: order direction must be set via Buy() or Sell()")
+	}
+	if o.volume <= 0 {
+		return errors.New("krakenapi: order volume must be positive")
+	}
+	if o.pairsCache != nil && o.pairInfo == nil {
+		return fmt.Errorf("krakenapi: no asset pair info cached for %s; call AssetPairsCache.Refresh first", o.pair)
+	}
+
+	if o.pairInfo != nil {
+		o.volume = roundDown(o.volume, o.pairInfo.LotDecimals)
+		// Trailing offsets are relative (or percentage) values, not
+		// absolute prices, so the pair's tick size doesn't apply to them.
+		if !o.isTrailing() {
+			o.price = roundToTick(o.price, o.pairInfo.PairDecimals)
+			o.price2 = roundToTick(o.price2, o.pairInfo.PairDecimals)
+		}
+
+		if min, err := strconv.ParseFloat(o.pairInfo.OrderMin, 64); err == nil && min > 0 && o.volume < min {
+			return fmt.Errorf("krakenapi: order volume %s below %s minimum %s",
+				formatPrice(o.volume), o.pair, o.pairInfo.OrderMin)
+		}
+	}
+	return nil
+}
+
+// Params renders the order as the args map expected by AddOrder,
+// running Validate first.
+func (o *OrderRequest) Params() (map[string]string, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	args := map[string]string{}
+	if len(o.oflags) > 0 {
+		args["oflags"] = strings.Join(o.oflags, ",")
+	}
+
+	switch o.orderType {
+	case OrderTypeLimit, OrderTypeStopLoss, OrderTypeTakeProfit:
+		args["price"] = formatPrice(o.price)
+	case OrderTypeStopLossLimit, OrderTypeTakeProfitLimit, OrderTypeStopLossAndLimit:
+		args["price"] = formatPrice(o.price)
+		args["price2"] = formatPrice(o.price2)
+	case OrderTypeTrailingStop:
+		// Kraken requires trailing offsets to be signed (and optionally
+		// %-suffixed); a bare number is read as an absolute trigger price.
+		args["price"] = formatOffset(o.price)
+	case OrderTypeTrailingStopLimit:
+		args["price"] = formatOffset(o.price)
+		args["price2"] = formatOffset(o.price2)
+	}
+
+	if o.leverage != "" {
+		args["leverage"] = o.leverage
+	}
+	if o.tif != "" {
+		args["timeinforce"] = o.tif
+		if o.tif == GTD && o.expireTM > 0 {
+			args["expiretm"] = strconv.FormatInt(o.expireTM, 10)
+		}
+	}
+	if o.startTM > 0 {
+		args["starttm"] = strconv.FormatInt(o.startTM, 10)
+	}
+	if o.closeType != "" {
+		args["close_order_type"] = o.closeType
+		args["close_price"] = formatPrice(o.closePrice)
+		if o.closePrice2 != 0 {
+			args["close_price2"] = formatPrice(o.closePrice2)
+		}
+	}
+	if o.validate {
+		args["validate"] = "true"
+	}
+
+	return args, nil
+}
+
+// Send validates and submits the order via api.AddOrder.
+func (o *OrderRequest) Send(api *KrakenAPI) (*AddOrderResponse, error) {
+	args, err := o.Params()
+	if err != nil {
+		return nil, err
+	}
+	return api.AddOrder(o.pair, o.direction, o.orderType, formatPrice(o.volume), args)
+}
+
+// isTrailing reports whether this order's price(s) are trailing
+// offsets rather than absolute prices.
+func (o *OrderRequest) isTrailing() bool {
+	return o.orderType == OrderTypeTrailingStop || o.orderType == OrderTypeTrailingStopLimit
+}
+
+func roundDown(v float64, decimals int) float64 {
+	mult := math.Pow(10, float64(decimals))
+	return math.Floor(v*mult) / mult
+}
+
+func roundToTick(v float64, decimals int) float64 {
+	if v == 0 {
+		return 0
+	}
+	mult := math.Pow(10, float64(decimals))
+	return math.Round(v*mult) / mult
+}
+
+func formatPrice(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatOffset renders a trailing-stop offset the way Kraken expects:
+// a sign-prefixed number (e.g. "+5" or "-12.5"), as opposed to the
+// bare number formatPrice produces for absolute prices.
+func formatOffset(v float64) string {
+	sign := "+"
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return sign + formatPrice(v)
+}
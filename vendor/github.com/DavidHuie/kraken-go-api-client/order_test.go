@@ -0,0 +1,106 @@
+package krakenapi
+
+import "testing"
+
+func cacheWithPair(pair string, info AssetPairInfo) *AssetPairsCache {
+	c := NewAssetPairsCache()
+	c.pairs = map[string]AssetPairInfo{pair: info}
+	return c
+}
+
+func TestOrderRequestRoundsToTickAndLotSize(t *testing.T) {
+	cache := cacheWithPair("XXBTZUSD", AssetPairInfo{PairDecimals: 1, LotDecimals: 4, OrderMin: "0.0001"})
+
+	o := NewOrder("XXBTZUSD").Buy().Limit(30123.456).Volume(0.123456).WithAssetPairs(cache)
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate() returned error: %s", err)
+	}
+
+	if got, want := o.price, 30123.5; got != want {
+		t.Errorf("price = %v, want %v (rounded to 1 decimal)", got, want)
+	}
+	if got, want := o.volume, 0.1234; got != want {
+		t.Errorf("volume = %v, want %v (rounded down to 4 decimals)", got, want)
+	}
+
+	params, err := o.Params()
+	if err != nil {
+		t.Fatalf("Params() returned error: %s", err)
+	}
+	if got, want := params["price"], "30123.5"; got != want {
+		t.Errorf("price param = %q, want %q", got, want)
+	}
+}
+
+func TestOrderRequestRejectsVolumeBelowOrderMin(t *testing.T) {
+	cache := cacheWithPair("XXBTZUSD", AssetPairInfo{PairDecimals: 1, LotDecimals: 8, OrderMin: "0.001"})
+
+	_, err := NewOrder("XXBTZUSD").Buy().Limit(30000).Volume(0.0001).
+		WithAssetPairs(cache).Params()
+	if err == nil {
+		t.Fatal("expected an error for volume below ordermin, got nil")
+	}
+}
+
+func TestOrderRequestRejectsMissingDirection(t *testing.T) {
+	_, err := NewOrder("XXBTZUSD").Limit(30000).Volume(1).Params()
+	if err == nil {
+		t.Fatal("expected an error when neither Buy() nor Sell() was called, got nil")
+	}
+}
+
+func TestOrderRequestRejectsUncachedPair(t *testing.T) {
+	cache := cacheWithPair("XXBTZUSD", AssetPairInfo{PairDecimals: 1, LotDecimals: 8, OrderMin: "0.001"})
+
+	_, err := NewOrder("XETHZUSD").Buy().Limit(2000).Volume(1).
+		WithAssetPairs(cache).Params()
+	if err == nil {
+		t.Fatal("expected an error when the cache has no entry for the order's pair, got nil")
+	}
+}
+
+func TestOrderRequestRejectsNonPositiveVolume(t *testing.T) {
+	_, err := NewOrder("XXBTZUSD").Buy().Limit(30000).Volume(0).Params()
+	if err == nil {
+		t.Fatal("expected an error for zero volume, got nil")
+	}
+}
+
+func TestTrailingStopOffsetIsSignPrefixedAndNotTickRounded(t *testing.T) {
+	cache := cacheWithPair("XXBTZUSD", AssetPairInfo{PairDecimals: 1, LotDecimals: 8, OrderMin: "0.001"})
+
+	params, err := NewOrder("XXBTZUSD").Sell().Volume(1).TrailingStop(50).
+		WithAssetPairs(cache).Params()
+	if err != nil {
+		t.Fatalf("Params() returned error: %s", err)
+	}
+	if got, want := params["price"], "+50"; got != want {
+		t.Errorf("trailing-stop price = %q, want %q", got, want)
+	}
+
+	params, err = NewOrder("XXBTZUSD").Sell().Volume(1).TrailingStopLimit(-12.345, 50).
+		WithAssetPairs(cache).Params()
+	if err != nil {
+		t.Fatalf("Params() returned error: %s", err)
+	}
+	if got, want := params["price"], "-12.345"; got != want {
+		t.Errorf("trailing-stop-limit price = %q, want %q (not rounded to pair_decimals)", got, want)
+	}
+	if got, want := params["price2"], "+50"; got != want {
+		t.Errorf("trailing-stop-limit price2 = %q, want %q", got, want)
+	}
+}
+
+func TestCloseStopLossLimitParams(t *testing.T) {
+	params, err := NewOrder("XXBTZUSD").Buy().Limit(30000).Volume(1).
+		CloseStopLossLimit(29000, 28900).Params()
+	if err != nil {
+		t.Fatalf("Params() returned error: %s", err)
+	}
+	if params["close_order_type"] != OrderTypeStopLossLimit {
+		t.Errorf("close_order_type = %q, want %q", params["close_order_type"], OrderTypeStopLossLimit)
+	}
+	if params["close_price"] != "29000" || params["close_price2"] != "28900" {
+		t.Errorf("close prices = %q/%q, want 29000/28900", params["close_price"], params["close_price2"])
+	}
+}
@@ -0,0 +1,88 @@
+package krakenapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimitCost overrides the default per-call counter increment for
+// methods Kraken weighs more heavily than a typical call. See
+// https://docs.kraken.com/rest/#section/Rate-Limits.
+var rateLimitCost = map[string]int{
+	"AddOrder":      2,
+	"CancelOrder":   2,
+	"Ledgers":       2,
+	"TradesHistory": 2,
+	"QueryLedgers":  2,
+}
+
+const (
+	defaultPublicCost  = 1
+	defaultPrivateCost = 1
+
+	// maxCounter and counterDecayPerSec model the Starter tier's
+	// API counter: it tops out at 15 and decays by roughly 1 point
+	// every 3 seconds.
+	maxCounter         = 15
+	counterDecayPerSec = 1.0 / 3.0
+)
+
+func costFor(method string, private bool) int {
+	if cost, ok := rateLimitCost[method]; ok {
+		return cost
+	}
+	if private {
+		return defaultPrivateCost
+	}
+	return defaultPublicCost
+}
+
+// rateLimiter models Kraken's server-side API counter locally so
+// doRequest can hold off calling rather than rely on the server to
+// reject with EAPI:Rate limit exceeded.
+type rateLimiter struct {
+	mu         sync.Mutex
+	counter    float64
+	lastUpdate time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{lastUpdate: time.Now()}
+}
+
+// wait blocks until method can be called without pushing the modelled
+// counter past maxCounter, then reserves its cost. It returns early
+// with ctx.Err() if ctx is canceled or its deadline expires while
+// waiting, so a saturated counter (up to ~45s of backlog at
+// maxCounter) can't hang a call past the caller's own deadline.
+func (r *rateLimiter) wait(ctx context.Context, method string, private bool) error {
+	cost := float64(costFor(method, private))
+
+	for {
+		r.mu.Lock()
+		r.decayLocked()
+		if r.counter+cost <= maxCounter {
+			r.counter += cost
+			r.mu.Unlock()
+			return nil
+		}
+		over := r.counter + cost - maxCounter
+		r.mu.Unlock()
+
+		select {
+		case <-time.After(time.Duration(over/counterDecayPerSec*float64(time.Second)) + time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *rateLimiter) decayLocked() {
+	now := time.Now()
+	r.counter -= now.Sub(r.lastUpdate).Seconds() * counterDecayPerSec
+	if r.counter < 0 {
+		r.counter = 0
+	}
+	r.lastUpdate = now
+}
@@ -0,0 +1,67 @@
+package krakenapi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCostForUsesOverrideThenDefaults(t *testing.T) {
+	if got := costFor("AddOrder", true); got != 2 {
+		t.Errorf("costFor(AddOrder) = %d, want 2", got)
+	}
+	if got := costFor("CancelOrder", true); got != 2 {
+		t.Errorf("costFor(CancelOrder) = %d, want 2", got)
+	}
+	if got := costFor("Balance", true); got != defaultPrivateCost {
+		t.Errorf("costFor(Balance) = %d, want defaultPrivateCost (%d)", got, defaultPrivateCost)
+	}
+	if got := costFor("Ticker", false); got != defaultPublicCost {
+		t.Errorf("costFor(Ticker) = %d, want defaultPublicCost (%d)", got, defaultPublicCost)
+	}
+}
+
+func TestRateLimiterWaitReservesUpToMaxCounter(t *testing.T) {
+	r := newRateLimiter()
+	ctx := context.Background()
+
+	// maxCounter is 15; AddOrder costs 2, so 7 calls (14) fit without
+	// blocking and an 8th would push the modelled counter over.
+	for i := 0; i < 7; i++ {
+		if err := r.wait(ctx, "AddOrder", true); err != nil {
+			t.Fatalf("wait() call %d returned error: %s", i, err)
+		}
+	}
+	if r.counter != 14 {
+		t.Fatalf("counter after 7 reservations = %v, want 14", r.counter)
+	}
+}
+
+func TestRateLimiterWaitIsInterruptibleByContext(t *testing.T) {
+	r := newRateLimiter()
+	ctx := context.Background()
+
+	// Saturate the counter so the next wait() must block.
+	for r.counter+float64(costFor("AddOrder", true)) <= maxCounter {
+		if err := r.wait(ctx, "AddOrder", true); err != nil {
+			t.Fatalf("wait() returned error while saturating: %s", err)
+		}
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- r.wait(cancelCtx, "AddOrder", true)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("wait() returned %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait() did not return promptly after ctx was canceled")
+	}
+}
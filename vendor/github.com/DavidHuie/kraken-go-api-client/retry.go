@@ -0,0 +1,128 @@
+package krakenapi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryConfig controls how doRequest retries transient failures.
+type RetryConfig struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	// Jitter is the fraction (0-1) of randomness added to each delay,
+	// so that many clients backing off at once don't retry in lockstep.
+	Jitter float64
+}
+
+// DefaultRetryConfig is used by New and NewWithClient unless
+// overridden with SetRetryPolicy.
+var DefaultRetryConfig = RetryConfig{
+	MaxAttempts:  3,
+	InitialDelay: 500 * time.Millisecond,
+	MaxDelay:     10 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// nonRetryableSubstrings are Kraken error classes that represent a
+// final decision by the server (bad order, bad credentials, ...) and
+// should never be retried.
+var nonRetryableSubstrings = []string{
+	"EOrder:",
+	"EFunding:",
+	"EAPI:Invalid key",
+	"EAPI:Invalid signature",
+	"EAPI:Invalid nonce",
+	"EGeneral:Permission denied",
+}
+
+// retryableSubstrings are Kraken error strings known to be transient.
+var retryableSubstrings = []string{
+	"EAPI:Rate limit exceeded",
+	"EService:Unavailable",
+	"EService:Busy",
+	"EGeneral:Temporary lockout",
+}
+
+// delay returns the backoff for the given zero-indexed attempt.
+func (c RetryConfig) delay(attempt int) time.Duration {
+	d := float64(c.InitialDelay) * math.Pow(c.Multiplier, float64(attempt))
+	if max := float64(c.MaxDelay); d > max {
+		d = max
+	}
+	if c.Jitter > 0 {
+		d += d * c.Jitter * (rand.Float64()*2 - 1)
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// transportError wraps a failure that happened before Kraken had a
+// chance to return a structured response (request construction,
+// network I/O, a malformed body): these are always worth retrying,
+// unlike a Kraken business error, which is only retryable when it
+// matches retryableSubstrings below.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return fmt.Sprintf("Could not execute request! (%s)", e.err.Error())
+}
+
+func (e *transportError) Unwrap() error { return e.err }
+
+// apiError is a structured error Kraken itself returned in the
+// response body's "error" field.
+type apiError struct {
+	msg string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("Could not execute request! (%s)", e.msg)
+}
+
+// isRetryableErr reports whether err (as returned by doRequestOnce)
+// represents a transient failure worth retrying. A transportError is
+// always retryable. An apiError is retryable only if it matches one
+// of retryableSubstrings; anything else - including Kraken error
+// codes this library doesn't recognize, such as
+// EGeneral:Invalid arguments or EQuery:Unknown asset pair - fails
+// fast rather than being retried MaxAttempts times on the assumption
+// that an unrecognized business error might be transient.
+func isRetryableErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var te *transportError
+	if errors.As(err, &te) {
+		return true
+	}
+
+	var ae *apiError
+	if !errors.As(err, &ae) {
+		return false
+	}
+
+	msg := ae.Error()
+	for _, s := range nonRetryableSubstrings {
+		if strings.Contains(msg, s) {
+			return false
+		}
+	}
+	for _, s := range retryableSubstrings {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package krakenapi
+
+import "testing"
+
+func TestIsRetryableErrTransportIsAlwaysRetryable(t *testing.T) {
+	err := &transportError{err: errNetworkStub{}}
+	if !isRetryableErr(err) {
+		t.Error("isRetryableErr(transportError) = false, want true")
+	}
+}
+
+func TestIsRetryableErrKnownBusinessErrors(t *testing.T) {
+	cases := map[string]bool{
+		"EAPI:Rate limit exceeded":   true,
+		"EService:Unavailable":       true,
+		"EService:Busy":              true,
+		"EGeneral:Temporary lockout": true,
+		"EOrder:Insufficient funds":  false,
+		"EAPI:Invalid key":           false,
+		"EAPI:Invalid nonce":         false,
+		"EGeneral:Permission denied": false,
+	}
+	for msg, want := range cases {
+		if got := isRetryableErr(&apiError{msg: msg}); got != want {
+			t.Errorf("isRetryableErr(apiError{%q}) = %v, want %v", msg, got, want)
+		}
+	}
+}
+
+func TestIsRetryableErrUnrecognizedBusinessErrorFailsFast(t *testing.T) {
+	// A Kraken error code this library has never seen before must not
+	// be retried just because it shares the generic
+	// "Could not execute request!" prefix with every other apiError.
+	err := &apiError{msg: "EGeneral:Invalid arguments"}
+	if isRetryableErr(err) {
+		t.Error("isRetryableErr(unrecognized apiError) = true, want false")
+	}
+}
+
+func TestIsRetryableErrNil(t *testing.T) {
+	if isRetryableErr(nil) {
+		t.Error("isRetryableErr(nil) = true, want false")
+	}
+}
+
+func TestRetryConfigDelayCapsAtMaxDelay(t *testing.T) {
+	cfg := RetryConfig{
+		InitialDelay: 1000,
+		MaxDelay:     5000,
+		Multiplier:   10,
+		Jitter:       0,
+	}
+	if got := cfg.delay(5); got != 5000 {
+		t.Errorf("delay(5) = %v, want capped at MaxDelay (5000)", got)
+	}
+}
+
+type errNetworkStub struct{}
+
+func (errNetworkStub) Error() string { return "connection refused" }
@@ -0,0 +1,789 @@
+package krakenapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// PublicWSURL is Kraken's public WebSocket feed endpoint.
+	PublicWSURL = "wss://ws.kraken.com"
+	// PrivateWSURL is Kraken's authenticated WebSocket feed endpoint.
+	PrivateWSURL = "wss://ws-auth.kraken.com"
+
+	wsPingInterval = 20 * time.Second
+	wsWriteTimeout = 5 * time.Second
+)
+
+// GetWebSocketsTokenResponse is returned by GetWebSocketsToken and is
+// used to authenticate a connection to PrivateWSURL.
+type GetWebSocketsTokenResponse struct {
+	Token   string  `json:"token"`
+	Expires float64 `json:"expires"`
+}
+
+// GetWebSocketsToken returns a token used to connect to Kraken's
+// private WebSockets feeds. Tokens are valid for 15 minutes from
+// issuance, or indefinitely once a connection using them is open.
+func (api *KrakenAPI) GetWebSocketsToken() (*GetWebSocketsTokenResponse, error) {
+	resp, err := api.queryPrivate(context.Background(), "GetWebSocketsToken", url.Values{}, &GetWebSocketsTokenResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*GetWebSocketsTokenResponse), nil
+}
+
+// TickerUpdate is a single update from a "ticker" subscription.
+type TickerUpdate struct {
+	Pair string
+	Ask  float64
+	Bid  float64
+	Last float64
+}
+
+// PriceLevel is a single price/volume entry in an order book.
+type PriceLevel struct {
+	Price     float64
+	Volume    float64
+	Timestamp float64
+}
+
+// BookUpdate is a snapshot or incremental update from a "book"
+// subscription. Snapshot is true when the update carried a full book
+// snapshot; nothing marks the updates that follow a reconnect.
+type BookUpdate struct {
+	Pair       string
+	Asks       []PriceLevel
+	Bids       []PriceLevel
+	Snapshot   bool
+	Checksum   uint32
+	ChecksumOK bool
+}
+
+// TradeStreamUpdate is a single trade from a "trade" subscription.
+type TradeStreamUpdate struct {
+	Pair   string
+	Price  float64
+	Volume float64
+	Time   float64
+	Buy    bool
+	Sell   bool
+	Market bool
+	Limit  bool
+}
+
+// OHLCUpdate is a single candle from an "ohlc" subscription.
+type OHLCUpdate struct {
+	Pair   string
+	Time   float64
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	VWAP   float64
+	Volume float64
+	Count  int
+}
+
+// OwnTradeUpdate is a single fill from an "ownTrades" subscription.
+type OwnTradeUpdate struct {
+	TradeID string
+	OrderID string
+	Pair    string
+	Type    string
+	Price   float64
+	Volume  float64
+	Time    float64
+}
+
+// OpenOrderUpdate is a single order state change from an
+// "openOrders" subscription.
+type OpenOrderUpdate struct {
+	OrderID string
+	Status  string
+	Pair    string
+}
+
+// StreamOption configures a Stream returned by NewStream.
+type StreamOption func(*Stream)
+
+// WithDialer overrides the websocket.Dialer used to connect, so
+// tests can swap the transport.
+func WithDialer(d *websocket.Dialer) StreamOption {
+	return func(s *Stream) { s.dialer = d }
+}
+
+// WithBookDepth sets the order book depth subscribed to and kept in
+// each local book (default 10). The checksum always covers the top 10
+// levels per side, whatever the depth.
+func WithBookDepth(depth int) StreamOption {
+	return func(s *Stream) { s.bookDepth = depth }
+}
+
+// subscription records enough to resubscribe after a reconnect.
+type subscription struct {
+	name    string
+	private bool
+	pairs   []string
+	payload map[string]interface{}
+}
+
+// Stream maintains a connection to Kraken's public and/or private
+// WebSocket feeds, delivering updates on typed channels and
+// transparently reconnecting and resubscribing on disconnect.
+type Stream struct {
+	api        *KrakenAPI
+	dialer     *websocket.Dialer
+	publicURL  string
+	privateURL string
+	bookDepth  int
+
+	mu      sync.Mutex
+	public  *websocket.Conn
+	private *websocket.Conn
+	token   string
+	subs    []subscription
+	books   map[string]*localOrderBook
+
+	tickers    chan TickerUpdate
+	bookCh     chan BookUpdate
+	trades     chan TradeStreamUpdate
+	ohlcs      chan OHLCUpdate
+	ownTrades  chan OwnTradeUpdate
+	openOrders chan OpenOrderUpdate
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewStream creates a Stream bound to api's credentials. Call one of
+// the Subscribe* methods to open a connection and start receiving
+// updates.
+func (api *KrakenAPI) NewStream(opts ...StreamOption) *Stream {
+	s := &Stream{
+		api:        api,
+		dialer:     websocket.DefaultDialer,
+		publicURL:  PublicWSURL,
+		privateURL: PrivateWSURL,
+		bookDepth:  10,
+		books:      make(map[string]*localOrderBook),
+		tickers:    make(chan TickerUpdate, 64),
+		bookCh:     make(chan BookUpdate, 64),
+		trades:     make(chan TradeStreamUpdate, 64),
+		ohlcs:      make(chan OHLCUpdate, 64),
+		ownTrades:  make(chan OwnTradeUpdate, 64),
+		openOrders: make(chan OpenOrderUpdate, 64),
+		closed:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SubscribeTicker subscribes to ticker updates for pairs.
+func (s *Stream) SubscribeTicker(pairs ...string) (<-chan TickerUpdate, error) {
+	if err := s.subscribe(subscription{name: "ticker", pairs: pairs}); err != nil {
+		return nil, err
+	}
+	return s.tickers, nil
+}
+
+// SubscribeBook subscribes to order book updates for pairs, maintaining
+// a local book per pair validated against Kraken's published checksum.
+func (s *Stream) SubscribeBook(pairs ...string) (<-chan BookUpdate, error) {
+	sub := subscription{
+		name:    "book",
+		pairs:   pairs,
+		payload: map[string]interface{}{"depth": s.bookDepth},
+	}
+	if err := s.subscribe(sub); err != nil {
+		return nil, err
+	}
+	return s.bookCh, nil
+}
+
+// SubscribeTrades subscribes to live trades for pairs.
+func (s *Stream) SubscribeTrades(pairs ...string) (<-chan TradeStreamUpdate, error) {
+	if err := s.subscribe(subscription{name: "trade", pairs: pairs}); err != nil {
+		return nil, err
+	}
+	return s.trades, nil
+}
+
+// SubscribeOHLC subscribes to candles of the given interval (in
+// minutes) for pairs.
+func (s *Stream) SubscribeOHLC(interval int, pairs ...string) (<-chan OHLCUpdate, error) {
+	sub := subscription{
+		name:    "ohlc",
+		pairs:   pairs,
+		payload: map[string]interface{}{"interval": interval},
+	}
+	if err := s.subscribe(sub); err != nil {
+		return nil, err
+	}
+	return s.ohlcs, nil
+}
+
+// SubscribeOwnTrades subscribes to the authenticated user's fills.
+func (s *Stream) SubscribeOwnTrades() (<-chan OwnTradeUpdate, error) {
+	if err := s.subscribe(subscription{name: "ownTrades", private: true}); err != nil {
+		return nil, err
+	}
+	return s.ownTrades, nil
+}
+
+// SubscribeOpenOrders subscribes to the authenticated user's order
+// state changes.
+func (s *Stream) SubscribeOpenOrders() (<-chan OpenOrderUpdate, error) {
+	if err := s.subscribe(subscription{name: "openOrders", private: true}); err != nil {
+		return nil, err
+	}
+	return s.openOrders, nil
+}
+
+// Close tears down both connections and stops any reconnect attempts.
+func (s *Stream) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	if s.public != nil {
+		err = s.public.Close()
+	}
+	if s.private != nil {
+		if cerr := s.private.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (s *Stream) subscribe(sub subscription) error {
+	s.mu.Lock()
+	s.subs = append(s.subs, sub)
+	s.mu.Unlock()
+
+	conn, err := s.connFor(sub.private)
+	if err != nil {
+		return err
+	}
+	return s.sendSubscribe(conn, sub)
+}
+
+func (s *Stream) connFor(private bool) (*websocket.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if private {
+		if s.private != nil {
+			return s.private, nil
+		}
+		if s.token == "" {
+			resp, err := s.api.GetWebSocketsToken()
+			if err != nil {
+				return nil, fmt.Errorf("could not obtain websockets token: %s", err.Error())
+			}
+			s.token = resp.Token
+		}
+		conn, _, err := s.dialer.Dial(s.privateURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not dial %s: %s", s.privateURL, err.Error())
+		}
+		s.private = conn
+		go s.readLoop(conn, true)
+		go s.heartbeat(conn)
+		return conn, nil
+	}
+
+	if s.public != nil {
+		return s.public, nil
+	}
+	conn, _, err := s.dialer.Dial(s.publicURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial %s: %s", s.publicURL, err.Error())
+	}
+	s.public = conn
+	go s.readLoop(conn, false)
+	go s.heartbeat(conn)
+	return conn, nil
+}
+
+func (s *Stream) sendSubscribe(conn *websocket.Conn, sub subscription) error {
+	payload := map[string]interface{}{"name": sub.name}
+	for k, v := range sub.payload {
+		payload[k] = v
+	}
+
+	msg := map[string]interface{}{
+		"event":        "subscribe",
+		"subscription": payload,
+	}
+	if sub.private {
+		payload["token"] = s.token
+	} else if len(sub.pairs) > 0 {
+		msg["pair"] = sub.pairs
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(msg)
+}
+
+func (s *Stream) heartbeat(conn *websocket.Conn) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(map[string]string{"event": "ping"}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Stream) readLoop(conn *websocket.Conn, private bool) {
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-s.closed:
+				return
+			default:
+			}
+			s.reconnect(private)
+			return
+		}
+		s.dispatch(raw)
+	}
+}
+
+// reconnect waits one second, redials the feed and replays every
+// subscription recorded for it. A failed dial is retried the same way
+// until one succeeds or the Stream is closed.
+func (s *Stream) reconnect(private bool) {
+	s.mu.Lock()
+	if private {
+		s.private = nil
+		s.token = ""
+	} else {
+		s.public = nil
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.closed:
+		return
+	case <-time.After(time.Second):
+	}
+
+	conn, err := s.connFor(private)
+	if err != nil {
+		log.Printf("krakenapi: reconnect failed: %s", err.Error())
+		go s.reconnect(private)
+		return
+	}
+
+	s.mu.Lock()
+	subs := make([]subscription, len(s.subs))
+	copy(subs, s.subs)
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		if sub.private != private {
+			continue
+		}
+		if err := s.sendSubscribe(conn, sub); err != nil {
+			log.Printf("krakenapi: resubscribe %q failed: %s", sub.name, err.Error())
+		}
+	}
+}
+
+// dispatch parses a single WebSocket frame, which is either a JSON
+// object (an "event" message such as heartbeat/subscriptionStatus) or
+// a JSON array (a data message: [channelID, payload..., channelName, pair]).
+func (s *Stream) dispatch(raw []byte) {
+	trimmed := strings.TrimSpace(string(raw))
+	if len(trimmed) == 0 {
+		return
+	}
+	if trimmed[0] == '{' {
+		// Event message (heartbeat, systemStatus, subscriptionStatus,
+		// pong, error...); nothing for callers to consume.
+		return
+	}
+
+	var frame []json.RawMessage
+	if err := json.Unmarshal(raw, &frame); err != nil || len(frame) < 3 {
+		return
+	}
+
+	channelName, _ := frame[len(frame)-2].MarshalJSON()
+	pairRaw, _ := frame[len(frame)-1].MarshalJSON()
+	pair := strings.Trim(string(pairRaw), `"`)
+	name := strings.Trim(string(channelName), `"`)
+
+	switch {
+	case name == "ticker":
+		s.handleTicker(pair, frame[1])
+	case strings.HasPrefix(name, "book"):
+		s.handleBook(pair, frame[1:len(frame)-2])
+	case name == "trade":
+		s.handleTrade(pair, frame[1])
+	case strings.HasPrefix(name, "ohlc"):
+		s.handleOHLC(pair, frame[1])
+	case name == "ownTrades":
+		s.handleOwnTrades(frame[1])
+	case name == "openOrders":
+		s.handleOpenOrders(frame[1])
+	}
+}
+
+func (s *Stream) handleTicker(pair string, data json.RawMessage) {
+	var raw struct {
+		Ask  []string `json:"a"`
+		Bid  []string `json:"b"`
+		Last []string `json:"c"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+	s.tickers <- TickerUpdate{
+		Pair: pair,
+		Ask:  parseFloatOrZero(first(raw.Ask)),
+		Bid:  parseFloatOrZero(first(raw.Bid)),
+		Last: parseFloatOrZero(first(raw.Last)),
+	}
+}
+
+func (s *Stream) handleTrade(pair string, data json.RawMessage) {
+	var trades [][]interface{}
+	if err := json.Unmarshal(data, &trades); err != nil {
+		return
+	}
+	for _, t := range trades {
+		if len(t) < 5 {
+			continue
+		}
+		side, _ := t[3].(string)
+		kind, _ := t[4].(string)
+		s.trades <- TradeStreamUpdate{
+			Pair:   pair,
+			Price:  parseFloatOrZero(toString(t[0])),
+			Volume: parseFloatOrZero(toString(t[1])),
+			Time:   parseFloatOrZero(toString(t[2])),
+			Buy:    side == "b",
+			Sell:   side == "s",
+			Market: kind == "m",
+			Limit:  kind == "l",
+		}
+	}
+}
+
+func (s *Stream) handleOHLC(pair string, data json.RawMessage) {
+	var c []interface{}
+	if err := json.Unmarshal(data, &c); err != nil || len(c) < 8 {
+		return
+	}
+	count, _ := c[7].(float64)
+	s.ohlcs <- OHLCUpdate{
+		Pair:   pair,
+		Time:   parseFloatOrZero(toString(c[0])),
+		Open:   parseFloatOrZero(toString(c[2])),
+		High:   parseFloatOrZero(toString(c[3])),
+		Low:    parseFloatOrZero(toString(c[4])),
+		Close:  parseFloatOrZero(toString(c[5])),
+		VWAP:   parseFloatOrZero(toString(c[6])),
+		Volume: parseFloatOrZero(toString(c[7])),
+		Count:  int(count),
+	}
+}
+
+func (s *Stream) handleOwnTrades(data json.RawMessage) {
+	var entries []map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		for tradeID, fields := range entry {
+			s.ownTrades <- OwnTradeUpdate{
+				TradeID: tradeID,
+				OrderID: toString(fields["ordertxid"]),
+				Pair:    toString(fields["pair"]),
+				Type:    toString(fields["type"]),
+				Price:   parseFloatOrZero(toString(fields["price"])),
+				Volume:  parseFloatOrZero(toString(fields["vol"])),
+				Time:    parseFloatOrZero(toString(fields["time"])),
+			}
+		}
+	}
+}
+
+func (s *Stream) handleOpenOrders(data json.RawMessage) {
+	var entries []map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		for orderID, fields := range entry {
+			s.openOrders <- OpenOrderUpdate{
+				OrderID: orderID,
+				Status:  toString(fields["status"]),
+				Pair:    toString(fields["descr"]),
+			}
+		}
+	}
+}
+
+// handleBook applies a snapshot or update payload (and, for updates,
+// the combined ask+bid delta that can arrive as two separate frame
+// elements) to the pair's local order book and validates the result
+// against Kraken's checksum when one is present.
+func (s *Stream) handleBook(pair string, parts []json.RawMessage) {
+	s.mu.Lock()
+	book, ok := s.books[pair]
+	if !ok {
+		book = newLocalOrderBook(s.bookDepth)
+		s.books[pair] = book
+	}
+	s.mu.Unlock()
+
+	var checksum uint32
+	var snapshot bool
+
+	for _, part := range parts {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(part, &raw); err != nil {
+			continue
+		}
+		if asks, ok := raw["as"]; ok {
+			book.applySnapshot(asks, true)
+			snapshot = true
+		}
+		if bids, ok := raw["bs"]; ok {
+			book.applySnapshot(bids, false)
+			snapshot = true
+		}
+		if asks, ok := raw["a"]; ok {
+			book.applyUpdate(asks, true)
+		}
+		if bids, ok := raw["b"]; ok {
+			book.applyUpdate(bids, false)
+		}
+		if c, ok := raw["c"]; ok {
+			if n, err := strconv.ParseUint(strings.Trim(string(c), `"`), 10, 32); err == nil {
+				checksum = uint32(n)
+			}
+		}
+	}
+
+	update := BookUpdate{
+		Pair:     pair,
+		Asks:     book.topAsks(),
+		Bids:     book.topBids(),
+		Snapshot: snapshot,
+	}
+	if checksum != 0 {
+		update.Checksum = checksum
+		update.ChecksumOK = book.checksum() == checksum
+	}
+	s.bookCh <- update
+}
+
+// localOrderBook maintains both sides of an order book as price ->
+// volume, keeping only the top `depth` levels per side, matching the
+// state Kraken's checksum is computed over.
+type localOrderBook struct {
+	depth int
+	asks  map[string]string
+	bids  map[string]string
+}
+
+func newLocalOrderBook(depth int) *localOrderBook {
+	return &localOrderBook{depth: depth, asks: map[string]string{}, bids: map[string]string{}}
+}
+
+func (b *localOrderBook) side(ask bool) map[string]string {
+	if ask {
+		return b.asks
+	}
+	return b.bids
+}
+
+func (b *localOrderBook) applySnapshot(raw json.RawMessage, ask bool) {
+	var levels [][]interface{}
+	if err := json.Unmarshal(raw, &levels); err != nil {
+		return
+	}
+	side := b.side(ask)
+	for k := range side {
+		delete(side, k)
+	}
+	for _, l := range levels {
+		if len(l) < 2 {
+			continue
+		}
+		side[toString(l[0])] = toString(l[1])
+	}
+}
+
+func (b *localOrderBook) applyUpdate(raw json.RawMessage, ask bool) {
+	var levels [][]interface{}
+	if err := json.Unmarshal(raw, &levels); err != nil {
+		return
+	}
+	side := b.side(ask)
+	for _, l := range levels {
+		if len(l) < 2 {
+			continue
+		}
+		price, volume := toString(l[0]), toString(l[1])
+		// Kraken formats volume at the pair's own precision, not
+		// always 8 decimals, so a zero-volume delete signal can
+		// arrive as "0.00", "0", etc. - compare numerically rather
+		// than against a fixed-width literal.
+		if parseFloatOrZero(volume) == 0 {
+			delete(side, price)
+			continue
+		}
+		side[price] = volume
+	}
+
+	// Kraken sends no delete for a level pushed out of the subscribed
+	// depth, so drop every level beyond the top `depth` here.
+	if len(side) > b.depth {
+		keep := make(map[string]bool, b.depth)
+		for _, l := range b.topRaw(ask, b.depth) {
+			keep[l.price] = true
+		}
+		for p := range side {
+			if !keep[p] {
+				delete(side, p)
+			}
+		}
+	}
+}
+
+func (b *localOrderBook) topAsks() []PriceLevel { return b.top(true) }
+func (b *localOrderBook) topBids() []PriceLevel { return b.top(false) }
+
+func (b *localOrderBook) top(ask bool) []PriceLevel {
+	levels := make([]PriceLevel, 0, b.depth)
+	for _, e := range b.topRaw(ask, b.depth) {
+		levels = append(levels, PriceLevel{Price: parseFloatOrZero(e.price), Volume: parseFloatOrZero(e.volume)})
+	}
+	return levels
+}
+
+// rawLevel is a price level with the exact strings Kraken sent over
+// the wire, as opposed to PriceLevel's parsed float64s.
+type rawLevel struct {
+	price  string
+	volume string
+}
+
+// topRaw returns the top n levels of side, sorted by numeric price
+// (ascending for asks, descending for bids), preserving the original
+// wire-format price/volume strings.
+func (b *localOrderBook) topRaw(ask bool, n int) []rawLevel {
+	side := b.side(ask)
+	prices := make([]float64, 0, len(side))
+	byPrice := make(map[float64]string, len(side))
+	for p := range side {
+		f := parseFloatOrZero(p)
+		prices = append(prices, f)
+		byPrice[f] = p
+	}
+	if ask {
+		sort.Float64s(prices)
+	} else {
+		sort.Sort(sort.Reverse(sort.Float64Slice(prices)))
+	}
+	if len(prices) > n {
+		prices = prices[:n]
+	}
+
+	levels := make([]rawLevel, 0, len(prices))
+	for _, p := range prices {
+		priceStr := byPrice[p]
+		levels = append(levels, rawLevel{price: priceStr, volume: side[priceStr]})
+	}
+	return levels
+}
+
+// checksum reproduces Kraken's order book checksum algorithm: the top
+// 10 ask levels (ascending) then the top 10 bid levels (descending),
+// each price and volume taken verbatim from the wire (decimal point
+// and leading zeros stripped, original digit count otherwise
+// preserved) and concatenated, then CRC32'd as a whole. This must
+// operate on the original strings rather than a parsed float64,
+// because reformatting to a fixed number of decimal places produces a
+// different byte sequence than Kraken used whenever a pair's price
+// decimals aren't already that many digits.
+func (b *localOrderBook) checksum() uint32 {
+	var sb strings.Builder
+	for _, l := range b.topRaw(true, 10) {
+		sb.WriteString(checksumToken(l.price))
+		sb.WriteString(checksumToken(l.volume))
+	}
+	for _, l := range b.topRaw(false, 10) {
+		sb.WriteString(checksumToken(l.price))
+		sb.WriteString(checksumToken(l.volume))
+	}
+	return crc32.ChecksumIEEE([]byte(sb.String()))
+}
+
+// checksumToken strips the decimal point and any leading zeros from a
+// wire-format price/volume string, matching Kraken's checksum input
+// format exactly.
+func checksumToken(s string) string {
+	s = strings.Replace(s, ".", "", 1)
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		s = "0"
+	}
+	return s
+}
+
+func parseFloatOrZero(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func first(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
@@ -0,0 +1,109 @@
+package krakenapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLocalOrderBookChecksum(t *testing.T) {
+	book := newLocalOrderBook(10)
+
+	asks, err := json.Marshal([][]string{{"5541.20000", "2.50700000", "1612186085"}})
+	if err != nil {
+		t.Fatalf("marshal asks: %s", err)
+	}
+	bids, err := json.Marshal([][]string{{"5541.10000", "1.00000000", "1612186085"}})
+	if err != nil {
+		t.Fatalf("marshal bids: %s", err)
+	}
+
+	book.applySnapshot(json.RawMessage(asks), true)
+	book.applySnapshot(json.RawMessage(bids), false)
+
+	// Expected value computed independently from Kraken's documented
+	// checksum algorithm: concatenate the top 10 ask levels (ascending)
+	// then the top 10 bid levels (descending), each price/volume with
+	// the decimal point and leading zeros stripped, then CRC32 (IEEE)
+	// the result.
+	const want = uint32(2222849657)
+	if got := book.checksum(); got != want {
+		t.Errorf("checksum() = %d, want %d", got, want)
+	}
+}
+
+func TestLocalOrderBookChecksumUsesWireStrings(t *testing.T) {
+	// A price with fewer decimal places than 8 must not be
+	// zero-padded out to 8 digits before hashing - Kraken's checksum
+	// is computed from the exact string it sent, whatever its
+	// precision, not a reformatted fixed-precision float.
+	book := newLocalOrderBook(10)
+
+	asks, err := json.Marshal([][]string{{"100.1", "5", "1612186085"}})
+	if err != nil {
+		t.Fatalf("marshal asks: %s", err)
+	}
+	book.applySnapshot(json.RawMessage(asks), true)
+
+	got := book.checksum()
+	wantToken := checksumToken("100.1") + checksumToken("5")
+	if wantToken != "10015" {
+		t.Fatalf("sanity check on checksumToken failed: got %q", wantToken)
+	}
+
+	// The checksum must be the CRC32 (IEEE) of the wire tokens "10015";
+	// this pins the behavior against a future regression to the old
+	// float64 round trip, which would instead hash "10010000000500000000".
+	const want = uint32(516798603)
+	if got != want {
+		t.Errorf("checksum() = %d, want %d (CRC32 of %q)", got, want, wantToken)
+	}
+
+	// Recomputing from the same raw strings must reproduce the value.
+	book2 := newLocalOrderBook(10)
+	book2.applySnapshot(json.RawMessage(asks), true)
+	if got2 := book2.checksum(); got != got2 {
+		t.Errorf("checksum() is not deterministic across equivalent books: %d != %d", got, got2)
+	}
+}
+
+func TestApplyUpdateDeletesZeroVolumeLevelAtAnyPrecision(t *testing.T) {
+	book := newLocalOrderBook(10)
+
+	snapshot, err := json.Marshal([][]string{{"100.10", "5.00", "1612186085"}})
+	if err != nil {
+		t.Fatalf("marshal snapshot: %s", err)
+	}
+	book.applySnapshot(json.RawMessage(snapshot), true)
+
+	if len(book.asks) != 1 {
+		t.Fatalf("after snapshot: len(asks) = %d, want 1", len(book.asks))
+	}
+
+	// Kraken formats a level's volume at the pair's own precision, not
+	// always 8 decimals; a delete signal for a 2-decimal pair arrives
+	// as "0.00", not "0.00000000".
+	update, err := json.Marshal([][]string{{"100.10", "0.00", "1612186086"}})
+	if err != nil {
+		t.Fatalf("marshal update: %s", err)
+	}
+	book.applyUpdate(json.RawMessage(update), true)
+
+	if len(book.asks) != 0 {
+		t.Errorf("after zero-volume update: len(asks) = %d, want 0 (level should be deleted)", len(book.asks))
+	}
+}
+
+func TestChecksumTokenStripsPointAndLeadingZeros(t *testing.T) {
+	cases := map[string]string{
+		"5541.20000":  "554120000",
+		"0.00001000":  "1000",
+		"100.1":       "1001",
+		"0.00000000":  "0",
+		"12345.00000": "1234500000",
+	}
+	for in, want := range cases {
+		if got := checksumToken(in); got != want {
+			t.Errorf("checksumToken(%q) = %q, want %q", in, got, want)
+		}
+	}
+}